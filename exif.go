@@ -0,0 +1,193 @@
+package gamma
+
+import (
+    "bytes"
+    "encoding/binary"
+    "image"
+    "image/color"
+    "io"
+)
+
+// orientationTagID is the EXIF tag number for the Orientation field
+// (TIFF/EXIF type SHORT).
+const orientationTagID = 0x0112
+
+// NewOrientedTransfer decodes the image read from r (the caller must
+// blank-import the relevant image/* package, e.g. image/jpeg, to register a
+// decoder), corrects it for its EXIF Orientation tag if present, and builds
+// a Transfer from the result using fn.
+//
+// Orientation 1 (or no EXIF data at all) is the common case and is passed
+// straight through to NewTransfer so its fast paths still apply; any other
+// orientation is corrected by wrapping the decoded image in an adapter that
+// remaps coordinates, which falls back to the generic At path.
+func NewOrientedTransfer(r io.Reader, fn TransferFunction) (*Transfer, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
+    }
+
+    img, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+
+    o := exifOrientation(data)
+    if o == 1 {
+        return NewTransfer(img, fn), nil
+    }
+
+    return NewTransfer(&orientedImage{src: img, orientation: o}, fn), nil
+}
+
+// exifOrientation scans a JPEG byte stream for an APP1 Exif segment and
+// returns its Orientation value (1-8). It returns 1, the identity
+// orientation, if data isn't a JPEG or carries no usable Orientation tag.
+func exifOrientation(data []byte) int {
+    if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+        return 1
+    }
+
+    i := 2
+    for i+4 <= len(data) {
+        if data[i] != 0xff {
+            break
+        }
+
+        marker := data[i+1]
+        if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd9) {
+            i += 2
+            continue
+        }
+        if marker == 0xda { // start of scan: no more markers follow
+            break
+        }
+
+        length := int(data[i+2])<<8 | int(data[i+3])
+        segStart := i + 4
+        segEnd := i + 2 + length
+        if length < 2 || segEnd > len(data) {
+            break
+        }
+
+        if marker == 0xe1 { // APP1
+            if o, ok := parseExifOrientation(data[segStart:segEnd]); ok {
+                return o
+            }
+        }
+
+        i = segEnd
+    }
+
+    return 1
+}
+
+// parseExifOrientation reads the Orientation tag out of an APP1 segment's
+// payload, which starts with the "Exif\0\0" marker followed by a TIFF
+// header.
+func parseExifOrientation(seg []byte) (int, bool) {
+    if len(seg) < 14 || string(seg[:6]) != "Exif\x00\x00" {
+        return 0, false
+    }
+
+    tiff := seg[6:]
+
+    var bo binary.ByteOrder
+    switch string(tiff[:2]) {
+    case "II":
+        bo = binary.LittleEndian
+    case "MM":
+        bo = binary.BigEndian
+    default:
+        return 0, false
+    }
+
+    if bo.Uint16(tiff[2:4]) != 0x002a {
+        return 0, false
+    }
+
+    ifdOffset := bo.Uint32(tiff[4:8])
+    if int(ifdOffset)+2 > len(tiff) {
+        return 0, false
+    }
+
+    n := int(bo.Uint16(tiff[ifdOffset : ifdOffset+2]))
+    entries := tiff[ifdOffset+2:]
+
+    for e := 0; e < n; e++ {
+        off := e * 12
+        if off+12 > len(entries) {
+            break
+        }
+
+        entry := entries[off : off+12]
+        if bo.Uint16(entry[0:2]) != orientationTagID {
+            continue
+        }
+        if bo.Uint16(entry[2:4]) != 3 { // SHORT
+            return 0, false
+        }
+
+        v := int(bo.Uint16(entry[8:10]))
+        if v < 1 || v > 8 {
+            return 0, false
+        }
+
+        return v, true
+    }
+
+    return 0, false
+}
+
+// orientedImage wraps src, remapping coordinates through the rotation/flip
+// described by an EXIF orientation value (2-8; 1 is handled without
+// wrapping by NewOrientedTransfer) so that Bounds and At report the
+// visually-correct image.
+type orientedImage struct {
+    src         image.Image
+    orientation int
+}
+
+func (o *orientedImage) ColorModel() color.Model {
+    return o.src.ColorModel()
+}
+
+func (o *orientedImage) Bounds() image.Rectangle {
+    b := o.src.Bounds()
+
+    switch o.orientation {
+    case 5, 6, 7, 8:
+        return image.Rect(0, 0, b.Dy(), b.Dx())
+    default:
+        return image.Rect(0, 0, b.Dx(), b.Dy())
+    }
+}
+
+func (o *orientedImage) At(x, y int) color.Color {
+    sx, sy := o.srcCoords(x, y)
+    return o.src.At(sx, sy)
+}
+
+func (o *orientedImage) srcCoords(x, y int) (int, int) {
+    b := o.src.Bounds()
+    w, h := b.Dx(), b.Dy()
+
+    switch o.orientation {
+    case 2: // mirror horizontal
+        return b.Min.X + (w - 1 - x), b.Min.Y + y
+    case 3: // rotate 180
+        return b.Min.X + (w - 1 - x), b.Min.Y + (h - 1 - y)
+    case 4: // mirror vertical
+        return b.Min.X + x, b.Min.Y + (h - 1 - y)
+    case 5: // transpose (mirror horizontal + rotate 270 CW)
+        return b.Min.X + y, b.Min.Y + x
+    case 6: // rotate 90 CW
+        return b.Min.X + y, b.Min.Y + (h - 1 - x)
+    case 7: // transverse (mirror horizontal + rotate 90 CW)
+        return b.Min.X + (w - 1 - y), b.Min.Y + (h - 1 - x)
+    case 8: // rotate 270 CW
+        return b.Min.X + (w - 1 - y), b.Min.Y + x
+    default: // 1: identity
+        return b.Min.X + x, b.Min.Y + y
+    }
+}
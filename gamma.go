@@ -10,12 +10,23 @@ type TransferFunction func(x float64) float64
 
 type Transfer struct {
     src     image.Image
-    op      func(t *Transfer, x, y int) color.NRGBA64
+    op      func(t *Transfer, x, y int) color.RGBA64
     stride  int
     minX    int
     minY    int
     pix     []uint8
-    ramp    []uint16
+    rampR   []uint16
+    rampG   []uint16
+    rampB   []uint16
+
+    // *image.YCbCr / *image.NYCbCrA fast path (opYCbCr)
+    y       []uint8
+    cb      []uint8
+    cr      []uint8
+    a       []uint8
+    cStride int
+    aStride int
+    ratio   image.YCbCrSubsampleRatio
 }
 
 func makeRamp(fn TransferFunction) (ramp []uint16) {
@@ -36,10 +47,33 @@ func makeRamp(fn TransferFunction) (ramp []uint16) {
     return
 }
 
+// NewTransfer builds a Transfer that applies fn identically to the R, G and
+// B channels of img.
 func NewTransfer(img image.Image, fn TransferFunction) *Transfer {
+    ramp := makeRamp(fn)
+    return newTransfer(img, ramp, ramp, ramp)
+}
+
+// NewTransferRGB is like NewTransfer but applies a separate transfer
+// function to each of the R, G and B channels.
+func NewTransferRGB(img image.Image, fnR, fnG, fnB TransferFunction) *Transfer {
+    return newTransfer(img, makeRamp(fnR), makeRamp(fnG), makeRamp(fnB))
+}
+
+// NewTransferLUT is like NewTransferRGB but takes precomputed 16-bit lookup
+// tables instead of TransferFunctions, e.g. ones loaded from an external
+// calibration file. Each of rampR, rampG and rampB must have 0x10000
+// entries.
+func NewTransferLUT(img image.Image, rampR, rampG, rampB []uint16) *Transfer {
+    return newTransfer(img, rampR, rampG, rampB)
+}
+
+func newTransfer(img image.Image, rampR, rampG, rampB []uint16) *Transfer {
     t := Transfer{
-        src:    img,
-        ramp:   makeRamp(fn),
+        src:   img,
+        rampR: rampR,
+        rampG: rampG,
+        rampB: rampB,
     }
 
     switch i := img.(type) {
@@ -71,6 +105,30 @@ func NewTransfer(img image.Image, fn TransferFunction) *Transfer {
         t.minX = i.Rect.Min.X
         t.minY = i.Rect.Min.Y
 
+    case *image.YCbCr:
+        t.op = (*Transfer).opYCbCr
+        t.y = i.Y
+        t.cb = i.Cb
+        t.cr = i.Cr
+        t.stride = i.YStride
+        t.cStride = i.CStride
+        t.ratio = i.SubsampleRatio
+        t.minX = i.Rect.Min.X
+        t.minY = i.Rect.Min.Y
+
+    case *image.NYCbCrA:
+        t.op = (*Transfer).opYCbCr
+        t.y = i.Y
+        t.cb = i.Cb
+        t.cr = i.Cr
+        t.a = i.A
+        t.stride = i.YStride
+        t.cStride = i.CStride
+        t.aStride = i.AStride
+        t.ratio = i.SubsampleRatio
+        t.minX = i.Rect.Min.X
+        t.minY = i.Rect.Min.Y
+
     default:
         t.op = (*Transfer).opGen
     }
@@ -79,7 +137,7 @@ func NewTransfer(img image.Image, fn TransferFunction) *Transfer {
 }
 
 func (t *Transfer) ColorModel() color.Model {
-    return color.NRGBA64Model
+    return color.RGBA64Model
 }
 
 func (t *Transfer) Bounds() image.Rectangle {
@@ -90,6 +148,10 @@ func (t *Transfer) At(x, y int) color.Color {
     return t.op(t, x, y)
 }
 
+func (t *Transfer) RGBA64At(x, y int) color.RGBA64 {
+    return t.op(t, x, y)
+}
+
 func Gamma(g float64) TransferFunction {
     return func(x float64) float64 {
         return math.Pow(x, g)
@@ -114,7 +176,19 @@ func SrgbForward(c float64) float64 {
 
 /* --------------------------------------------------------------------------------- */
 
-func (t *Transfer) opNRGBA(x, y int) color.NRGBA64 {
+// premultiply applies the already-gamma-corrected, non-premultiplied r, g, b
+// channels against alpha a, producing a color.RGBA64 as required by
+// image.RGBA64Image / draw.RGBA64Image.
+func premultiply(r, g, b, a uint16) color.RGBA64 {
+    return color.RGBA64{
+        R: uint16(uint32(r) * uint32(a) / 0xffff),
+        G: uint16(uint32(g) * uint32(a) / 0xffff),
+        B: uint16(uint32(b) * uint32(a) / 0xffff),
+        A: a,
+    }
+}
+
+func (t *Transfer) opNRGBA(x, y int) color.RGBA64 {
     offs := (y - t.minY) * t.stride + (x - t.minX) * 4
 
     r := uint16(t.pix[offs + 0]) << 8
@@ -122,10 +196,10 @@ func (t *Transfer) opNRGBA(x, y int) color.NRGBA64 {
     b := uint16(t.pix[offs + 2]) << 8
     a := uint16(t.pix[offs + 3]) << 8
 
-    return color.NRGBA64{t.ramp[r], t.ramp[g], t.ramp[b], a}
+    return premultiply(t.rampR[r], t.rampG[g], t.rampB[b], a)
 }
 
-func (t *Transfer) opNRGBA64(x, y int) color.NRGBA64 {
+func (t *Transfer) opNRGBA64(x, y int) color.RGBA64 {
     offs := (y - t.minY) * t.stride + (x - t.minX) * 8
 
     r := uint16(t.pix[offs + 0]) << 8 | uint16(t.pix[offs + 1])
@@ -133,10 +207,10 @@ func (t *Transfer) opNRGBA64(x, y int) color.NRGBA64 {
     b := uint16(t.pix[offs + 4]) << 8 | uint16(t.pix[offs + 5])
     a := uint16(t.pix[offs + 6]) << 8 | uint16(t.pix[offs + 7])
 
-    return color.NRGBA64{t.ramp[r], t.ramp[g], t.ramp[b], a}
+    return premultiply(t.rampR[r], t.rampG[g], t.rampB[b], a)
 }
 
-func (t *Transfer) opRGBA(x, y int) color.NRGBA64 {
+func (t *Transfer) opRGBA(x, y int) color.RGBA64 {
     offs := (y - t.minY) * t.stride + (x - t.minX) * 4
 
     r := uint32(t.pix[offs + 0]) << 8
@@ -150,10 +224,10 @@ func (t *Transfer) opRGBA(x, y int) color.NRGBA64 {
         b = (b * 0xffff) / a
     }
 
-    return color.NRGBA64{t.ramp[r], t.ramp[g], t.ramp[b], uint16(a)}
+    return premultiply(t.rampR[r], t.rampG[g], t.rampB[b], uint16(a))
 }
 
-func (t *Transfer) opRGBA64(x, y int) color.NRGBA64 {
+func (t *Transfer) opRGBA64(x, y int) color.RGBA64 {
     offs := (y - t.minY) * t.stride + (x - t.minX) * 8
 
     r := uint32(t.pix[offs + 0]) << 8 | uint32(t.pix[offs + 1])
@@ -167,17 +241,74 @@ func (t *Transfer) opRGBA64(x, y int) color.NRGBA64 {
         b = (b * 0xffff) / a
     }
 
-    return color.NRGBA64{t.ramp[r], t.ramp[g], t.ramp[b], uint16(a)}
+    return premultiply(t.rampR[r], t.rampG[g], t.rampB[b], uint16(a))
 }
 
-func (t *Transfer) opGen(x, y int) color.NRGBA64 {
+// cOffset computes the chroma-plane index for luma coordinates (x, y),
+// mirroring image.YCbCr.COffset for each subsample ratio.
+func cOffset(ratio image.YCbCrSubsampleRatio, x, y, minX, minY, cStride int) int {
+    switch ratio {
+    case image.YCbCrSubsampleRatio422:
+        return (y - minY) * cStride + (x/2 - minX/2)
+    case image.YCbCrSubsampleRatio420:
+        return (y/2 - minY/2) * cStride + (x/2 - minX/2)
+    case image.YCbCrSubsampleRatio440:
+        return (y/2 - minY/2) * cStride + (x - minX)
+    case image.YCbCrSubsampleRatio410:
+        return (y/2 - minY/2) * cStride + (x/4 - minX/4)
+    case image.YCbCrSubsampleRatio411:
+        return (y - minY) * cStride + (x/4 - minX/4)
+    default: // image.YCbCrSubsampleRatio444
+        return (y - minY) * cStride + (x - minX)
+    }
+}
+
+func (t *Transfer) opYCbCr(x, y int) color.RGBA64 {
+    yi := (y - t.minY) * t.stride + (x - t.minX)
+    ci := cOffset(t.ratio, x, y, t.minX, t.minY, t.cStride)
+
+    // Inline ITU-R BT.601 YCbCr -> RGB, same matrix as color.YCbCrToRGB.
+    yy1 := int32(t.y[yi]) * 0x10101
+    cb1 := int32(t.cb[ci]) - 128
+    cr1 := int32(t.cr[ci]) - 128
+
+    r := yy1 + 91881*cr1
+    if uint32(r)&0xff000000 == 0 {
+        r >>= 16
+    } else {
+        r = ^(r >> 31)
+    }
+
+    g := yy1 - 22554*cb1 - 46802*cr1
+    if uint32(g)&0xff000000 == 0 {
+        g >>= 16
+    } else {
+        g = ^(g >> 31)
+    }
+
+    b := yy1 + 116130*cb1
+    if uint32(b)&0xff000000 == 0 {
+        b >>= 16
+    } else {
+        b = ^(b >> 31)
+    }
+
+    a := uint16(0xffff)
+    if t.a != nil {
+        a = uint16(t.a[(y-t.minY)*t.aStride+(x-t.minX)]) << 8
+    }
+
+    return premultiply(t.rampR[uint16(r)<<8], t.rampG[uint16(g)<<8], t.rampB[uint16(b)<<8], a)
+}
+
+func (t *Transfer) opGen(x, y int) color.RGBA64 {
     r, g, b, a := t.src.At(x, y).RGBA()
- 
+
     if a != 0 {
         r = (r * 0xffff) / a
         g = (g * 0xffff) / a
         b = (b * 0xffff) / a
     }
 
-    return color.NRGBA64{t.ramp[r], t.ramp[g], t.ramp[b], uint16(a)}
+    return premultiply(t.rampR[r], t.rampG[g], t.rampB[b], uint16(a))
 }
\ No newline at end of file
@@ -0,0 +1,111 @@
+package gamma
+
+import (
+    "image"
+    "image/color"
+    "image/draw"
+    "runtime"
+    "sync"
+)
+
+const tileHeight = 64
+
+// Render materialises t into dst, covering t.Bounds(). It splits the work
+// into horizontal tiles and processes them concurrently across
+// runtime.NumCPU() workers, so it is considerably faster than driving dst
+// through draw.Draw(dst, t.Bounds(), t, sp, draw.Src) pixel by pixel.
+func Render(dst draw.Image, t *Transfer) error {
+    return RenderRect(dst, t, t.Bounds())
+}
+
+// RenderRect is like Render but only materialises the given subregion of t.
+func RenderRect(dst draw.Image, t *Transfer, r image.Rectangle) error {
+    r = r.Intersect(t.Bounds())
+    r = r.Intersect(dst.Bounds())
+    if r.Empty() {
+        return nil
+    }
+
+    n := runtime.NumCPU()
+
+    tiles := make(chan image.Rectangle, n)
+
+    var wg sync.WaitGroup
+    wg.Add(n)
+
+    for i := 0; i < n; i++ {
+        go func() {
+            defer wg.Done()
+
+            for tile := range tiles {
+                renderTile(dst, t, tile)
+            }
+        }()
+    }
+
+    for y := r.Min.Y; y < r.Max.Y; y += tileHeight {
+        y1 := y + tileHeight
+        if y1 > r.Max.Y {
+            y1 = r.Max.Y
+        }
+
+        tiles <- image.Rect(r.Min.X, y, r.Max.X, y1)
+    }
+    close(tiles)
+
+    wg.Wait()
+
+    return nil
+}
+
+func renderTile(dst draw.Image, t *Transfer, r image.Rectangle) {
+    switch d := dst.(type) {
+    case *image.NRGBA64:
+        for y := r.Min.Y; y < r.Max.Y; y++ {
+            off := d.PixOffset(r.Min.X, y)
+
+            for x := r.Min.X; x < r.Max.X; x++ {
+                putNRGBA64(d.Pix, off, t.op(t, x, y))
+                off += 8
+            }
+        }
+
+    default:
+        if rd, ok := dst.(draw.RGBA64Image); ok {
+            for y := r.Min.Y; y < r.Max.Y; y++ {
+                for x := r.Min.X; x < r.Max.X; x++ {
+                    rd.SetRGBA64(x, y, t.op(t, x, y))
+                }
+            }
+        } else {
+            for y := r.Min.Y; y < r.Max.Y; y++ {
+                for x := r.Min.X; x < r.Max.X; x++ {
+                    dst.Set(x, y, t.op(t, x, y))
+                }
+            }
+        }
+    }
+}
+
+// putNRGBA64 writes c, which is premultiplied, into the NRGBA64.Pix slice
+// pix at byte offset off, unpremultiplying it back to the straight-alpha
+// representation that *image.NRGBA64 stores, without boxing it through the
+// color.Color interface.
+func putNRGBA64(pix []uint8, off int, c color.RGBA64) {
+    r, g, b, a := uint32(c.R), uint32(c.G), uint32(c.B), uint32(c.A)
+
+    if a != 0 && a != 0xffff {
+        r = r * 0xffff / a
+        g = g * 0xffff / a
+        b = b * 0xffff / a
+    }
+
+    pix[off+0] = uint8(r >> 8)
+    pix[off+1] = uint8(r)
+    pix[off+2] = uint8(g >> 8)
+    pix[off+3] = uint8(g)
+    pix[off+4] = uint8(b >> 8)
+    pix[off+5] = uint8(b)
+    pix[off+6] = uint8(a >> 8)
+    pix[off+7] = uint8(a)
+}